@@ -0,0 +1,266 @@
+package wait
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/memsql/errors"
+)
+
+// NamedCondition pairs a human-readable name with a condition to poll, for
+// use with ForAll and ForAny. The name identifies the condition in progress
+// reports and in the errors returned once polling stops.
+type NamedCondition struct {
+	Name  string
+	Check func() (bool, error)
+}
+
+// ForAll polls every condition concurrently, each on its own goroutine with
+// its own backoff schedule, until all of them succeed or the shared
+// deadline passes. It returns one error per condition name (nil for
+// conditions that succeeded) along with an aggregated error, built with
+// errors.Join, that is non-nil if any condition failed.
+func ForAll(conditions []NamedCondition, options ...Option) (map[string]error, error) {
+	opts := defaultOptions()
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	ctx, cancel := conditionContext(opts)
+	defer cancel()
+
+	startTime := opts.Clock.Now()
+	tracker := newPendingTracker(opts, conditionNames(conditions), startTime, startTime.Add(opts.TimeLimit))
+	defer tracker.stop()
+
+	results := make(map[string]error, len(conditions))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, cond := range conditions {
+		cond := cond
+		condOpts := conditionOptions(opts, ctx, cond.Name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := For(cond.Check, condOpts...)
+			tracker.done(cond.Name)
+			mu.Lock()
+			results[cond.Name] = err
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	var failures []error
+	for _, name := range conditionNames(conditions) {
+		if err := results[name]; err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return results, errors.Join(failures...)
+}
+
+// ForAny polls every condition concurrently and returns the name and error
+// of the first one to succeed. The remaining conditions are canceled once a
+// winner is found. If every condition fails, or the shared deadline passes
+// before any of them succeed, ForAny returns an empty name and an
+// aggregated error built with errors.Join.
+func ForAny(conditions []NamedCondition, options ...Option) (string, error) {
+	opts := defaultOptions()
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	ctx, cancel := conditionContext(opts)
+	defer cancel()
+
+	startTime := opts.Clock.Now()
+	tracker := newPendingTracker(opts, conditionNames(conditions), startTime, startTime.Add(opts.TimeLimit))
+	defer tracker.stop()
+
+	type outcome struct {
+		name    string
+		err     error
+		success bool
+	}
+	resultCh := make(chan outcome, len(conditions))
+
+	var wg sync.WaitGroup
+	for _, cond := range conditions {
+		cond := cond
+		condOpts := conditionOptions(opts, ctx, cond.Name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// success records whether Check itself ever reported ok, rather
+			// than inferring success from the shape of the error For
+			// returns: a condition that exits early via ExitOnError(true)
+			// with a real processing error returns a non-nil err that isn't
+			// ErrTimeout/context.Canceled/context.DeadlineExceeded either,
+			// so it must not be mistaken for a winner.
+			var success bool
+			check := func() (bool, error) {
+				ok, err := cond.Check()
+				success = success || ok
+				return ok, err
+			}
+			err := For(check, condOpts...)
+			tracker.done(cond.Name)
+			resultCh <- outcome{name: cond.Name, err: err, success: success}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	var failures []error
+	for result := range resultCh {
+		if result.success {
+			cancel()
+			return result.name, result.err
+		}
+		failures = append(failures, fmt.Errorf("%s: %w", result.name, result.err))
+	}
+	return "", errors.Join(failures...)
+}
+
+// conditionContext derives the shared context that bounds ForAll/ForAny:
+// canceled when the caller's own context (if any) is canceled, or when
+// opts.Clock reaches the shared deadline. It uses opts.Clock rather than
+// context.WithTimeout, which is hardwired to the real clock, so the
+// deadline can be driven deterministically by a waittest.FakeClock.
+func conditionContext(opts O) (context.Context, context.CancelFunc) {
+	parent := opts.Ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	stopTimer := func() { stopOnce.Do(func() { close(stop) }) }
+	go func() {
+		select {
+		case <-opts.Clock.After(opts.TimeLimit):
+			cancel()
+		case <-stop:
+		}
+	}()
+	return ctx, func() {
+		stopTimer()
+		cancel()
+	}
+}
+
+// conditionOptions builds the per-condition option list for ForAll/ForAny:
+// the shared schedule and limiter, a per-condition description, the shared
+// context, and its own progress reporting disabled in favor of the
+// aggregate pendingTracker. It must be called sequentially, once per
+// condition, before the per-condition goroutines start: it reads from
+// opts.RandSource (when set) to derive each condition's own *rand.Rand,
+// since *rand.Rand is not safe to share across the concurrent For calls
+// ForAll/ForAny run.
+func conditionOptions(opts O, ctx context.Context, name string) []Option {
+	result := []Option{
+		WithLimit(opts.TimeLimit),
+		WithMinInterval(opts.StartInterval),
+		WithMaxInterval(opts.MaxInterval),
+		WithBackoff(opts.Backoff),
+		WithLogger(opts.Logger),
+		WithReports(0),
+		WithDescription(name),
+		WithContext(ctx),
+		ExitOnError(opts.ExitOnError),
+		WithJitter(opts.Jitter),
+		WithClock(opts.Clock),
+		func(o *O) { o.Limiter = opts.Limiter },
+	}
+	if opts.RandSource != nil {
+		result = append(result, WithRandSource(rand.New(rand.NewSource(opts.RandSource.Int63()))))
+	}
+	return result
+}
+
+func conditionNames(conditions []NamedCondition) []string {
+	names := make([]string, len(conditions))
+	for i, cond := range conditions {
+		names[i] = cond.Name
+	}
+	return names
+}
+
+// pendingTracker periodically logs the names of conditions that have not
+// yet finished, standing in for the per-call Reporter used by For when
+// polling several named conditions at once.
+type pendingTracker struct {
+	mu      sync.Mutex
+	pending map[string]bool
+	opts    O
+	stopCh  chan struct{}
+}
+
+func newPendingTracker(opts O, names []string, startTime, limit time.Time) *pendingTracker {
+	pending := make(map[string]bool, len(names))
+	for _, name := range names {
+		pending[name] = true
+	}
+	t := &pendingTracker{pending: pending, opts: opts, stopCh: make(chan struct{})}
+	if opts.Reports > 0 {
+		go t.run(startTime, limit)
+	}
+	return t
+}
+
+// run logs the names of still-pending conditions on a schedule driven by
+// t.opts.Clock rather than a time.Ticker (which is hardwired to the real
+// clock), so the aggregate reporting can be driven deterministically by a
+// waittest.FakeClock just like the per-condition polling in forCore.
+func (t *pendingTracker) run(startTime, limit time.Time) {
+	interval := limit.Sub(startTime) / time.Duration(t.opts.Reports+1)
+	if interval <= 0 {
+		return
+	}
+	next := startTime
+	for {
+		next = next.Add(interval)
+		select {
+		case <-t.stopCh:
+			return
+		case <-t.opts.Clock.After(next.Sub(t.opts.Clock.Now())):
+			names := t.names()
+			if len(names) == 0 {
+				return
+			}
+			t.opts.Logger("%s-%s wait for %s, still pending: %s",
+				startTime.UTC().Format("15:04:05"), t.opts.Clock.Now().UTC().Format("15:04:05"), t.opts.Description, strings.Join(names, ", "))
+		}
+	}
+}
+
+func (t *pendingTracker) done(name string) {
+	t.mu.Lock()
+	delete(t.pending, name)
+	t.mu.Unlock()
+}
+
+func (t *pendingTracker) names() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := make([]string, 0, len(t.pending))
+	for name := range t.pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *pendingTracker) stop() {
+	close(t.stopCh)
+}
@@ -0,0 +1,222 @@
+package wait_test
+
+import (
+	"errors"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/wait"
+	"github.com/singlestore-labs/wait/waittest"
+)
+
+func TestForAllSucceeds(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	var aCount, bCount int32
+	results, err := wait.ForAll([]wait.NamedCondition{
+		{Name: "a", Check: func() (bool, error) {
+			return atomic.AddInt32(&aCount, 1) >= 2, nil
+		}},
+		{Name: "b", Check: func() (bool, error) {
+			return atomic.AddInt32(&bCount, 1) >= 3, nil
+		}},
+	}, wait.WithLogger(logger.Log), wait.WithInterval(time.Microsecond*windowsMult()))
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.NoError(t, results["a"])
+	assert.NoError(t, results["b"])
+}
+
+func TestForAllReportsFailures(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	results, err := wait.ForAll([]wait.NamedCondition{
+		{Name: "ok", Check: func() (bool, error) {
+			return true, nil
+		}},
+		{Name: "never", Check: func() (bool, error) {
+			return false, nil
+		}},
+	}, wait.WithLogger(logger.Log),
+		wait.WithLimit(time.Millisecond*50*windowsMult()),
+		wait.WithInterval(time.Millisecond*10*windowsMult()))
+
+	assert.Error(t, err)
+	assert.NoError(t, results["ok"])
+	assert.Error(t, results["never"])
+}
+
+func TestForAnyReturnsFirstWinner(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	var slowCount int32
+	name, err := wait.ForAny([]wait.NamedCondition{
+		{Name: "fast", Check: func() (bool, error) {
+			return true, nil
+		}},
+		{Name: "slow", Check: func() (bool, error) {
+			atomic.AddInt32(&slowCount, 1)
+			return false, nil
+		}},
+	}, wait.WithLogger(logger.Log), wait.WithInterval(time.Millisecond*5*windowsMult()))
+
+	require.NoError(t, err)
+	assert.Equal(t, "fast", name)
+}
+
+func TestForAllWithSharedRandSourceIsRaceFree(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	shared := rand.New(rand.NewSource(1))
+	conditions := make([]wait.NamedCondition, 5)
+	for i := range conditions {
+		var calls int32
+		conditions[i] = wait.NamedCondition{
+			Name: string(rune('a' + i)),
+			Check: func() (bool, error) {
+				return atomic.AddInt32(&calls, 1) >= 3, nil
+			},
+		}
+	}
+
+	_, err := wait.ForAll(conditions,
+		wait.WithLogger(logger.Log),
+		wait.WithJitter(wait.JitterFull),
+		wait.WithRandSource(shared),
+		wait.WithInterval(time.Millisecond*windowsMult()))
+
+	require.NoError(t, err)
+}
+
+func TestForAnyExitOnErrorIsNotMistakenForSuccess(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	processingErr := errors.New("processing error")
+	name, err := wait.ForAny([]wait.NamedCondition{
+		{Name: "broken", Check: func() (bool, error) {
+			return false, processingErr
+		}},
+	}, wait.WithLogger(logger.Log),
+		wait.ExitOnError(true),
+		wait.WithLimit(time.Millisecond*50*windowsMult()),
+		wait.WithInterval(time.Millisecond*10*windowsMult()))
+
+	assert.Error(t, err)
+	assert.Equal(t, "", name)
+	assert.ErrorIs(t, err, processingErr)
+}
+
+func TestForAllWithFakeClockCompletesWithoutRealTime(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	clock := waittest.NewFakeClock(time.Unix(0, 0))
+	var aCalls, bCalls int32
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := wait.ForAll([]wait.NamedCondition{
+			{Name: "a", Check: func() (bool, error) {
+				return atomic.AddInt32(&aCalls, 1) >= 3, nil
+			}},
+			{Name: "b", Check: func() (bool, error) {
+				return atomic.AddInt32(&bCalls, 1) >= 5, nil
+			}},
+		}, wait.WithLogger(logger.Log),
+			wait.WithClock(clock),
+			wait.WithLimit(time.Hour),
+			wait.WithInterval(time.Minute),
+			wait.WithReports(3))
+		errCh <- err
+	}()
+
+	// Every Advance must wait for exactly the waiters live at that point,
+	// the same way TestWaitWithFakeClockDeterministicElapsed waits for
+	// forCore's single sleep waiter: here there are two long-lived waiters
+	// for the whole test (conditionContext's shared deadline and
+	// pendingTracker's report schedule, both far beyond the minute-scale
+	// advances below) plus one sleep waiter per condition still in flight.
+	// forCore's self-correcting interval scheme catches a condition fully
+	// up to its next multiple-of-interval deadline in a single Advance, so
+	// "a" (3 calls) finishes after just one 1-minute advance and "b" (5
+	// calls) after a second.
+	clock.BlockUntil(4) // conditionContext + pendingTracker + a + b
+	clock.Advance(time.Minute)
+	clock.BlockUntil(3) // a has succeeded and stopped waiting; b continues
+	clock.Advance(time.Minute)
+
+	err := <-errCh
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, int(atomic.LoadInt32(&aCalls)), 3)
+	assert.GreaterOrEqual(t, int(atomic.LoadInt32(&bCalls)), 5)
+}
+
+func TestForAnyHonorsFakeClockDeadline(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	clock := waittest.NewFakeClock(time.Unix(0, 0))
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := wait.ForAny([]wait.NamedCondition{
+			{Name: "never", Check: func() (bool, error) {
+				return false, nil
+			}},
+		}, wait.WithLogger(logger.Log),
+			wait.WithClock(clock),
+			wait.WithLimit(time.Hour),
+			wait.WithInterval(time.Minute),
+			wait.WithReports(0))
+		errCh <- err
+	}()
+
+	// With reporting disabled there are exactly two live waiters until the
+	// deadline: conditionContext's shared deadline and the condition's own
+	// recurring sleep. BlockUntil(2) before each Advance, rather than a
+	// real sleep, confirms both have re-registered before the clock moves
+	// again.
+	clock.BlockUntil(2)
+	select {
+	case err := <-errCh:
+		t.Fatalf("ForAny returned before its fake-clock deadline was reached: %v", err)
+	default:
+	}
+
+	const minutesPerHour = 60
+	for i := 0; i < minutesPerHour; i++ {
+		clock.BlockUntil(2)
+		clock.Advance(time.Minute)
+	}
+
+	err := <-errCh
+	assert.Error(t, err)
+}
+
+func TestForAnyAllFail(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	name, err := wait.ForAny([]wait.NamedCondition{
+		{Name: "a", Check: func() (bool, error) {
+			return false, nil
+		}},
+		{Name: "b", Check: func() (bool, error) {
+			return false, nil
+		}},
+	}, wait.WithLogger(logger.Log),
+		wait.WithLimit(time.Millisecond*50*windowsMult()),
+		wait.WithInterval(time.Millisecond*10*windowsMult()))
+
+	assert.Error(t, err)
+	assert.Equal(t, "", name)
+}
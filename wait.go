@@ -3,9 +3,11 @@ package wait
 import (
 	"context"
 	"log"
+	"math/rand"
 	"time"
 
 	"github.com/memsql/errors"
+	"golang.org/x/time/rate"
 )
 
 type O struct {
@@ -19,8 +21,45 @@ type O struct {
 	Description   string
 	Ctx           context.Context
 	ExitOnError   bool
+	Limiter       *rate.Limiter
+	Jitter        JitterStrategy
+	RandSource    *rand.Rand
+	Clock         Clock
 }
 
+// Clock abstracts the time reads and sleeps that forCore otherwise makes
+// directly against the time package, so tests can inject a fake
+// implementation instead of waiting on the real clock.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the production Clock: it calls time.Now, time.Sleep, and
+// time.After directly.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// JitterStrategy selects how the interval between calls to f is randomized.
+type JitterStrategy int
+
+const (
+	// JitterNone keeps the deterministic backoff interval (the default).
+	JitterNone JitterStrategy = iota
+	// JitterFull replaces the deterministic next interval with
+	// rand.Float64() * interval, bounded by [StartInterval, MaxInterval].
+	JitterFull
+	// JitterDecorrelated follows the AWS Architecture Blog recurrence
+	// sleep = min(MaxInterval, rand.Float64()*(prevSleep*3-StartInterval)+StartInterval),
+	// starting with prevSleep = StartInterval. It spreads retries without
+	// the unbounded growth of a tracked backoff factor.
+	JitterDecorrelated
+)
+
 type Logger func(fmt string, args ...any)
 
 type Reporter func(opts O, startTime time.Time)
@@ -47,6 +86,38 @@ func WithBackoff(f float64) Option { return func(o *O) { o.Backoff = f } }
 // Values must be 0 and above.
 func WithReports(n int) Option { return func(o *O) { o.Reports = n } }
 
+// WithRateLimit constructs a per-call token-bucket limiter and uses it to
+// cap how often f is invoked, regardless of how the backoff interval is
+// configured. The limiter is not shared with any other wait. When the
+// polled operation hits a shared upstream with a QPS budget that multiple
+// concurrent waits must not exceed in aggregate, pass the same limiter to
+// each of them with WithLimiter instead.
+func WithRateLimit(r rate.Limit, burst int) Option {
+	return func(o *O) { o.Limiter = rate.NewLimiter(r, burst) }
+}
+
+// WithLimiter sets a shared rate limiter that a token must be acquired from
+// before each invocation of f. Unlike WithRateLimit, the same *rate.Limiter
+// can be passed to many concurrent For calls so they share one budget.
+func WithLimiter(l *rate.Limiter) Option { return func(o *O) { o.Limiter = l } }
+
+// WithJitter randomizes the interval between calls to f using the given
+// strategy, so that many callers starting at roughly the same wall-clock
+// moment don't stay phase-locked and retry in synchronized waves.
+func WithJitter(strategy JitterStrategy) Option { return func(o *O) { o.Jitter = strategy } }
+
+// WithRandSource overrides the source of randomness used by WithJitter.
+// The default is a *rand.Rand seeded from time.Now().UnixNano(); tests
+// that need deterministic jitter should supply their own.
+func WithRandSource(r *rand.Rand) Option { return func(o *O) { o.RandSource = r } }
+
+// WithClock overrides the Clock used to read the time and to sleep between
+// iterations. The default is a real-clock implementation backed by
+// time.Now, time.Sleep, and time.After; tests that need exact, instant
+// control over elapsed time should supply their own, such as
+// waittest.FakeClock.
+func WithClock(c Clock) Option { return func(o *O) { o.Clock = c } }
+
 // WithInterval sets both the minimum and maximum intervals
 func WithInterval(d time.Duration) Option {
 	return func(o *O) {
@@ -58,7 +129,15 @@ func WithInterval(d time.Duration) Option {
 const ErrTimeout errors.String = "timeout"
 
 func defaultReporter(opts O, startTime time.Time) {
-	opts.Logger("%s-%s wait for %s, in progress", startTime.UTC().Format("15:04:05"), time.Now().UTC().Format("15:04:05"), opts.Description)
+	opts.Logger("%s-%s wait for %s, in progress", startTime.UTC().Format("15:04:05"), opts.Clock.Now().UTC().Format("15:04:05"), opts.Description)
+}
+
+// Result carries the bookkeeping a caller needs to know how much work
+// a completed wait actually did, beyond the success or failure of the
+// condition itself.
+type Result struct {
+	Iterations int
+	Elapsed    time.Duration
 }
 
 // For calls a function repeatedly.
@@ -67,7 +146,39 @@ func defaultReporter(opts O, startTime time.Time) {
 // If the function returns (true, error), then For() returns that error.
 // An error return from function parameter does not cause the loop to exit unless ExitOnError(true) is is set.
 func For(f func() (bool, error), options ...Option) error {
-	initialOpts := &O{
+	_, err := ForDetailed(f, options...)
+	return err
+}
+
+// ForDetailed behaves exactly like For but also returns a Result reporting
+// how many times f was called and how much time elapsed, so callers don't
+// have to wire up their own counter just to find out.
+func ForDetailed(f func() (bool, error), options ...Option) (Result, error) {
+	_, result, err := forCore(func() (struct{}, bool, error) {
+		ok, err := f()
+		return struct{}{}, ok, err
+	}, options...)
+	return result, err
+}
+
+// ForValue calls f repeatedly, returning the value it produced once it
+// reports success. It is the generic sibling of For for callers that need
+// to get a value out of the polled condition instead of closing over an
+// outer variable. On timeout it returns the zero value of T.
+func ForValue[T any](f func() (T, bool, error), options ...Option) (T, error) {
+	value, _, err := forCore(f, options...)
+	return value, err
+}
+
+// ForValueOK is ForValue with the same iterations/elapsed reporting as
+// ForDetailed, in the style of samber/lo's WaitFor.
+func ForValueOK[T any](f func() (T, bool, error), options ...Option) (value T, iterations int, elapsed time.Duration, err error) {
+	value, result, err := forCore(f, options...)
+	return value, result.Iterations, result.Elapsed, err
+}
+
+func defaultOptions() O {
+	return O{
 		TimeLimit:     time.Minute * 30,
 		StartInterval: time.Second,
 		MaxInterval:   time.Minute,
@@ -76,38 +187,91 @@ func For(f func() (bool, error), options ...Option) error {
 		Reports:       30,
 		Reporter:      defaultReporter,
 		Description:   "condition",
+		Clock:         realClock{},
 	}
+}
+
+func forCore[T any](f func() (T, bool, error), options ...Option) (T, Result, error) {
+	initialOpts := defaultOptions()
 
 	for _, opt := range options {
-		opt(initialOpts)
+		opt(&initialOpts)
 	}
 
-	opts := *initialOpts
+	opts := initialOpts
 
-	startTime := time.Now()
+	startTime := opts.Clock.Now()
 	limit := startTime.Add(opts.TimeLimit)
 	prior := startTime
 	interval := opts.StartInterval
+	prevSleep := opts.StartInterval
 	var reportsGiven int
+	var iterations int
+
+	randSource := opts.RandSource
+	if randSource == nil && opts.Jitter != JitterNone {
+		randSource = rand.New(rand.NewSource(opts.Clock.Now().UnixNano()))
+	}
+
+	var baseCtx context.Context
+	var limiterCtx context.Context
+	if opts.Limiter != nil {
+		// Bound the limiter wait by the shared deadline so a slow or
+		// exhausted limiter can't keep f() from ever running past
+		// opts.TimeLimit: without this, a limiterCtx derived only from
+		// opts.Ctx (which is commonly nil, i.e. context.Background) never
+		// expires on its own.
+		baseCtx = opts.Ctx
+		if baseCtx == nil {
+			baseCtx = context.Background()
+		}
+		var limiterCancel context.CancelFunc
+		limiterCtx, limiterCancel = context.WithDeadline(baseCtx, limit)
+		defer limiterCancel()
+	}
 
 	for {
-		ok, err := f()
+		iterations++
+		if opts.Limiter != nil {
+			if err := opts.Limiter.Wait(limiterCtx); err != nil {
+				// baseCtx can only have errored here if the caller's own
+				// opts.Ctx was canceled; any other error (including the
+				// rate package's own "would exceed context deadline" check,
+				// which fires before ever blocking on a canceled channel)
+				// is a consequence of the deadline this loop added, so it
+				// belongs on the same ErrTimeout path as the other ways
+				// this loop gives up.
+				if baseCtx.Err() != nil {
+					var zero T
+					return zero, Result{Iterations: iterations, Elapsed: opts.Clock.Now().Sub(startTime)}, err
+				}
+				now := opts.Clock.Now()
+				var zero T
+				return zero, Result{Iterations: iterations, Elapsed: now.Sub(startTime)},
+					ErrTimeout.Errorf("%s to %s wait for %s gave up after %s: %w",
+						startTime.Format("15:04:05"), now.Format("15:04:05"), opts.Description, now.Sub(startTime), err)
+			}
+		}
+		value, ok, err := f()
+		result := Result{Iterations: iterations, Elapsed: opts.Clock.Now().Sub(startTime)}
 		if ok {
 			// propagate error, if any
-			return err
+			return value, result, err
 		}
 		if err != nil && opts.ExitOnError {
-			return err
+			return value, result, err
 		}
 
-		now := time.Now()
+		now := opts.Clock.Now()
 
 		if now.After(limit) {
+			var zero T
+			result = Result{Iterations: iterations, Elapsed: now.Sub(startTime)}
 			if err != nil {
-				return ErrTimeout.Errorf("%s to %s wait for %s gave up after %s: %w",
+				return zero, result, ErrTimeout.Errorf("%s to %s wait for %s gave up after %s: %w",
 					startTime.Format("15:04:05"), now.Format("15:04:05"), opts.Description, now.Sub(startTime), err)
 			} else {
-				return ErrTimeout.Errorf("%s to %s wait for %s gave up after %s: not ok",
+				return zero, result, ErrTimeout.Errorf("%s to %s wait for %s gave up after %s: not ok",
 					startTime.Format("15:04:05"), now.Format("15:04:05"), opts.Description, now.Sub(startTime))
 			}
 		}
@@ -115,32 +279,65 @@ func For(f func() (bool, error), options ...Option) error {
 		if opts.Reports > 0 && float64(reportsGiven+1)/float64(opts.Reports+1) < float64(now.Sub(startTime))/float64(limit.Sub(startTime)) {
 			opts.Reporter(opts, startTime)
 			reportsGiven++
-			now = time.Now()
+			now = opts.Clock.Now()
 		}
 
-		next := prior.Add(interval)
-		prior = now
-		interval = time.Duration(opts.Backoff * float64(interval))
-		if interval > opts.MaxInterval {
-			interval = opts.MaxInterval
-		}
-		if next.After(limit) {
-			next = limit
-		}
-		thisSleep := next.Sub(now)
-		if thisSleep < 0 {
-			continue
+		var thisSleep time.Duration
+		if opts.Jitter == JitterNone {
+			next := prior.Add(interval)
+			prior = now
+			interval = time.Duration(opts.Backoff * float64(interval))
+			if interval > opts.MaxInterval {
+				interval = opts.MaxInterval
+			}
+			if next.After(limit) {
+				next = limit
+			}
+			thisSleep = next.Sub(now)
+			if thisSleep < 0 {
+				continue
+			}
+		} else {
+			// Jittered sleeps are measured from now rather than from the
+			// self-correcting prior/next scheme above: that scheme assumes
+			// a slowly, monotonically growing interval, which a randomized
+			// one is not.
+			var sleepInterval time.Duration
+			switch opts.Jitter {
+			case JitterFull:
+				sleepInterval = time.Duration(randSource.Float64() * float64(interval))
+				if sleepInterval < opts.StartInterval {
+					sleepInterval = opts.StartInterval
+				}
+			case JitterDecorrelated:
+				sleepInterval = time.Duration(randSource.Float64()*(float64(prevSleep)*3-float64(opts.StartInterval)) + float64(opts.StartInterval))
+			}
+			if sleepInterval > opts.MaxInterval {
+				sleepInterval = opts.MaxInterval
+			}
+			prevSleep = sleepInterval
+			prior = now
+			interval = time.Duration(opts.Backoff * float64(interval))
+			if interval > opts.MaxInterval {
+				interval = opts.MaxInterval
+			}
+
+			thisSleep = sleepInterval
+			if now.Add(thisSleep).After(limit) {
+				thisSleep = limit.Sub(now)
+			}
 		}
 
 		if opts.Ctx != nil {
 			select {
 			case <-opts.Ctx.Done():
-				return opts.Ctx.Err()
-			case <-time.After(thisSleep):
+				var zero T
+				return zero, Result{Iterations: iterations, Elapsed: opts.Clock.Now().Sub(startTime)}, opts.Ctx.Err()
+			case <-opts.Clock.After(thisSleep):
 				//
 			}
 		} else {
-			time.Sleep(thisSleep)
+			opts.Clock.Sleep(thisSleep)
 		}
 	}
 }
@@ -3,13 +3,18 @@ package wait_test
 import (
 	"fmt"
 	"os"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"math/rand"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 
 	"github.com/singlestore-labs/wait"
+	"github.com/singlestore-labs/wait/waittest"
 )
 
 type logger struct {
@@ -80,6 +85,209 @@ func TestWaitIncrease(t *testing.T) {
 	assert.Less(t, firstThree, lastThree)
 }
 
+func TestForValue(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	t.Log("value produced once condition succeeds")
+	count := 0
+	value, err := wait.ForValue(func() (string, bool, error) {
+		count++
+		if count >= 3 {
+			return "done", true, nil
+		}
+		return "", false, nil
+	}, wait.WithLogger(logger.Log), wait.WithInterval(time.Microsecond*windowsMult()))
+	require.NoError(t, err)
+	assert.Equal(t, "done", value)
+	assert.Equal(t, 3, count)
+
+	t.Log("zero value on timeout")
+	value, err = wait.ForValue(func() (string, bool, error) {
+		return "never", false, nil
+	}, wait.WithLogger(logger.Log), wait.WithLimit(time.Millisecond*50*windowsMult()), wait.WithInterval(time.Millisecond*10*windowsMult()))
+	assert.Error(t, err)
+	assert.Equal(t, "", value)
+}
+
+func TestForValueOK(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	count := 0
+	value, iterations, elapsed, err := wait.ForValueOK(func() (int, bool, error) {
+		count++
+		return count, count >= 4, nil
+	}, wait.WithLogger(logger.Log), wait.WithInterval(time.Microsecond*windowsMult()))
+	require.NoError(t, err)
+	assert.Equal(t, 4, value)
+	assert.Equal(t, 4, iterations)
+	assert.GreaterOrEqual(t, elapsed, time.Duration(0))
+}
+
+func TestForDetailed(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	count := 0
+	result, err := wait.ForDetailed(func() (bool, error) {
+		count++
+		return count >= 5, nil
+	}, wait.WithLogger(logger.Log), wait.WithInterval(time.Microsecond*windowsMult()))
+	require.NoError(t, err)
+	assert.Equal(t, 5, result.Iterations)
+	assert.GreaterOrEqual(t, result.Elapsed, time.Duration(0))
+}
+
+func TestWaitRateLimit(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	t.Log("rate limit caps how often f is called even with a tiny backoff interval")
+	start := time.Now()
+	count := 0
+	require.NoError(t, wait.For(func() (bool, error) {
+		count++
+		return count >= 5, nil
+	}, wait.WithLogger(logger.Log),
+		wait.WithInterval(time.Microsecond*windowsMult()),
+		wait.WithRateLimit(rate.Limit(1000), 1)))
+	assert.Equal(t, 5, count)
+	// 4 gaps at >= ~1ms each (1000/sec allows one token per ms after the burst is spent).
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*3*windowsMult())
+}
+
+func TestWaitLimiterShared(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	limiter := rate.NewLimiter(rate.Limit(1000), 2)
+	count := 0
+	require.NoError(t, wait.For(func() (bool, error) {
+		count++
+		return count >= 2, nil
+	}, wait.WithLogger(logger.Log),
+		wait.WithInterval(time.Microsecond*windowsMult()),
+		wait.WithLimiter(limiter)))
+	assert.Equal(t, 2, count)
+	assert.Less(t, limiter.Tokens(), float64(2))
+}
+
+func TestWaitRateLimitHonorsTimeLimit(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	t.Log("a limiter too slow to ever hand out a token still times out by WithLimit")
+	start := time.Now()
+	err := wait.For(func() (bool, error) {
+		return false, nil
+	}, wait.WithLogger(logger.Log),
+		wait.WithLimit(time.Millisecond*50*windowsMult()),
+		wait.WithInterval(time.Millisecond*windowsMult()),
+		wait.WithRateLimit(rate.Limit(0.001), 1))
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, wait.ErrTimeout)
+	assert.Less(t, time.Since(start), time.Second*2*windowsMult())
+}
+
+func TestWaitJitterFull(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	var intervals []time.Duration
+	prior := time.Now()
+	count := 0
+	require.NoError(t, wait.For(func() (bool, error) {
+		this := time.Now()
+		if count > 0 {
+			intervals = append(intervals, this.Sub(prior))
+		}
+		prior = this
+		count++
+		return count >= 6, nil
+	}, wait.WithLogger(logger.Log),
+		wait.WithMinInterval(time.Millisecond*5*windowsMult()),
+		wait.WithMaxInterval(time.Millisecond*50*windowsMult()),
+		wait.WithBackoff(1.4),
+		wait.WithJitter(wait.JitterFull),
+		wait.WithRandSource(rand.New(rand.NewSource(1)))))
+	assert.Equal(t, 5, len(intervals))
+	for _, interval := range intervals {
+		assert.GreaterOrEqual(t, interval, time.Millisecond*5*windowsMult())
+		assert.LessOrEqual(t, interval, time.Millisecond*60*windowsMult())
+	}
+}
+
+func TestWaitJitterDecorrelated(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	var intervals []time.Duration
+	prior := time.Now()
+	count := 0
+	require.NoError(t, wait.For(func() (bool, error) {
+		this := time.Now()
+		if count > 0 {
+			intervals = append(intervals, this.Sub(prior))
+		}
+		prior = this
+		count++
+		return count >= 6, nil
+	}, wait.WithLogger(logger.Log),
+		wait.WithMinInterval(time.Millisecond*5*windowsMult()),
+		wait.WithMaxInterval(time.Millisecond*50*windowsMult()),
+		wait.WithJitter(wait.JitterDecorrelated),
+		wait.WithRandSource(rand.New(rand.NewSource(1)))))
+	assert.Equal(t, 5, len(intervals))
+	for _, interval := range intervals {
+		assert.GreaterOrEqual(t, interval, time.Millisecond*5*windowsMult())
+		assert.LessOrEqual(t, interval, time.Millisecond*60*windowsMult())
+	}
+}
+
+func TestWaitWithFakeClockDeterministicElapsed(t *testing.T) {
+	t.Parallel()
+	logger := &logger{t: t}
+
+	clock := waittest.NewFakeClock(time.Unix(0, 0))
+
+	const wantIterations = 10
+	var calls int32
+	type outcome struct {
+		result wait.Result
+		err    error
+	}
+	resultCh := make(chan outcome, 1)
+	go func() {
+		result, err := wait.ForDetailed(func() (bool, error) {
+			return int(atomic.AddInt32(&calls, 1)) >= wantIterations, nil
+		}, wait.WithLogger(logger.Log),
+			wait.WithClock(clock),
+			wait.WithLimit(time.Hour),
+			wait.WithMinInterval(time.Millisecond*5),
+			wait.WithMaxInterval(time.Second),
+			wait.WithBackoff(1.4))
+		resultCh <- outcome{result, err}
+	}()
+
+	var wantElapsed time.Duration
+	interval := time.Millisecond * 5
+	for i := 0; i < wantIterations-1; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(interval)
+		wantElapsed += interval
+		interval = time.Duration(1.4 * float64(interval))
+		if interval > time.Second {
+			interval = time.Second
+		}
+	}
+
+	out := <-resultCh
+	require.NoError(t, out.err)
+	assert.Equal(t, wantIterations, out.result.Iterations)
+	assert.Equal(t, wantElapsed, out.result.Elapsed)
+}
+
 func isWindows() bool {
 	return os.PathSeparator == '\\' && os.PathListSeparator == ';'
 }
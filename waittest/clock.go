@@ -0,0 +1,104 @@
+// Package waittest provides a deterministic wait.Clock for tests that need
+// exact control over elapsed time instead of real wall-clock delays.
+package waittest
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/singlestore-labs/wait"
+)
+
+var _ wait.Clock = (*FakeClock)(nil)
+
+// FakeClock is a wait.Clock that never advances on its own: Now reports
+// whatever time was last set, and Sleep/After block until a call to
+// Advance moves the clock to or past their deadline, at which point they fire
+// synchronously rather than waiting on a real timer. This mirrors how
+// x/time/rate tests inject time.Time values into the limiter instead of
+// sleeping in wall-clock time.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock returns a FakeClock starting at the given time.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the clock's current time, as last set by NewFakeClock or
+// advanced by Advance.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep blocks until Advance moves the clock at least d past its current
+// time.
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// After returns a channel that receives the deadline once Advance moves the
+// clock at least d past its current time. A non-positive d fires
+// immediately.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	deadline := c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- deadline
+		return ch
+	}
+	c.waiters = append(c.waiters, &waiter{deadline: deadline, ch: ch})
+	return ch
+}
+
+// BlockUntil blocks until at least n calls to Sleep or After are pending,
+// so a test can drive Advance only once the code under test has actually
+// registered its next wait rather than racing it.
+func (c *FakeClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		count := len(c.waiters)
+		c.mu.Unlock()
+		if count >= n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+// Advance moves the clock forward by d, firing any pending Sleep/After
+// calls whose deadline has been reached, in order from earliest to latest.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	var due, remaining []*waiter
+	for _, w := range c.waiters {
+		if !w.deadline.After(now) {
+			due = append(due, w)
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	c.waiters = remaining
+	c.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return due[i].deadline.Before(due[j].deadline) })
+	for _, w := range due {
+		w.ch <- w.deadline
+	}
+}
@@ -0,0 +1,74 @@
+package waittest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/singlestore-labs/wait/waittest"
+)
+
+func TestFakeClockSleepFiresOnAdvance(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := waittest.NewFakeClock(start)
+
+	done := make(chan struct{})
+	go func() {
+		clock.Sleep(time.Second)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Sleep returned before Advance")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	clock.Advance(time.Second)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Sleep did not return after Advance")
+	}
+
+	assert.Equal(t, start.Add(time.Second), clock.Now())
+}
+
+func TestFakeClockAdvanceFiresEveryDueAfter(t *testing.T) {
+	t.Parallel()
+
+	clock := waittest.NewFakeClock(time.Unix(0, 0))
+
+	long := clock.After(time.Second * 10)
+	short := clock.After(time.Second)
+
+	clock.Advance(time.Second * 10)
+
+	select {
+	case <-short:
+	default:
+		t.Fatal("short deadline did not fire")
+	}
+	select {
+	case <-long:
+	default:
+		t.Fatal("long deadline did not fire")
+	}
+}
+
+func TestFakeClockNonPositiveDurationFiresImmediately(t *testing.T) {
+	t.Parallel()
+
+	clock := waittest.NewFakeClock(time.Unix(0, 0))
+	select {
+	case <-clock.After(0):
+	default:
+		t.Fatal("zero duration After should fire without Advance")
+	}
+	require.NotNil(t, clock)
+}